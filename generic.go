@@ -0,0 +1,157 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package tagheap
+
+import (
+	"container/heap"
+	"errors"
+	"reflect"
+)
+
+// Heap is a generic, struct-tag-driven heap of *T.
+//
+// Unlike TagHeap, Heap[T] stores its elements internally rather than
+// operating over a caller-owned slice, and its Push, Pop, Remove, and Fix
+// methods take and return *T directly, so callers need no type assertions.
+// Internally, elements pass through container/heap as interface{} values.
+// A Heap constructed with NewGeneric uses no reflection at all.
+type Heap[T any] genHeap[T]
+
+// unexported type implementing heap.Interface
+type genHeap[T any] struct {
+	s        []*T
+	less     func(a, b *T) bool
+	getIndex func(u *T) int
+	setIndex func(u *T, i int)
+}
+
+// Option configures a Heap[T] constructed with NewGeneric.
+type Option[T any] func(*genHeap[T])
+
+// WithIndex configures a Heap[T] to maintain an index on each element as
+// it is pushed, swapped, or popped, analogous to the `heap:"index"`
+// struct tag of TagHeap.  The index is required by Remove and Fix.
+func WithIndex[T any](get func(u *T) int, set func(u *T, i int)) Option[T] {
+	return func(g *genHeap[T]) {
+		g.getIndex = get
+		g.setIndex = set
+	}
+}
+
+// NewGeneric constructs a new Heap[T] ordered by less.
+//
+// less must report whether a should sort before b.  opts may be used to
+// configure index maintenance with WithIndex, enabling Remove and Fix.
+func NewGeneric[T any](less func(a, b *T) bool, opts ...Option[T]) *Heap[T] {
+	g := &genHeap[T]{less: less}
+	for _, opt := range opts {
+		opt(g)
+	}
+	heap.Init(g)
+	return (*Heap[T])(g)
+}
+
+// NewFor constructs a new Heap[T] using struct tags on T to determine
+// heap order, the way TagHeap's New does.  The tags on T are parsed once,
+// at construction.  The less function and index accessors built from them
+// still use reflection on each call to read and set the tagged fields.
+//
+// Argument key is the tag key to recognize in struct tags on T, following
+// the same grammar documented on the tagheap package: `heap:"min"` and
+// `heap:"max"`, their composite ",rank" and ",cmp=name" forms, and
+// `heap:"index"`.  T must be a struct type; the heap stores *T.
+func NewFor[T any](key string) (*Heap[T], error) {
+	var zero T
+	st := reflect.TypeOf(zero)
+	if st == nil || st.Kind() != reflect.Struct {
+		return nil, errors.New("type argument must be a struct type")
+	}
+	keyFields, indexFieldIndex, err := parseTagFields(st, key)
+	if err != nil {
+		return nil, err
+	}
+	cmpFn := compositeLess(keyFields)
+	less := func(a, b *T) bool {
+		return cmpFn(reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem())
+	}
+	var opts []Option[T]
+	if indexFieldIndex >= 0 {
+		get := func(u *T) int {
+			return int(reflect.ValueOf(u).Elem().Field(indexFieldIndex).Int())
+		}
+		set := func(u *T, i int) {
+			reflect.ValueOf(u).Elem().Field(indexFieldIndex).SetInt(int64(i))
+		}
+		opts = append(opts, WithIndex(get, set))
+	}
+	return NewGeneric(less, opts...), nil
+}
+
+// method of heap.Interface
+func (g genHeap[T]) Len() int { return len(g.s) }
+
+// method of heap.Interface
+func (g genHeap[T]) Less(i, j int) bool { return g.less(g.s[i], g.s[j]) }
+
+// method of heap.Interface
+func (g *genHeap[T]) Swap(i, j int) {
+	g.s[i], g.s[j] = g.s[j], g.s[i]
+	if g.setIndex != nil {
+		g.setIndex(g.s[i], i)
+		g.setIndex(g.s[j], j)
+	}
+}
+
+// method of heap.Interface
+func (g *genHeap[T]) Push(u interface{}) {
+	p := u.(*T)
+	if g.setIndex != nil {
+		g.setIndex(p, len(g.s))
+	}
+	g.s = append(g.s, p)
+}
+
+// method of heap.Interface
+func (g *genHeap[T]) Pop() interface{} {
+	l := len(g.s) - 1
+	p := g.s[l]
+	g.s = g.s[:l]
+	return p
+}
+
+// Len returns the number of elements on the heap.
+func (h *Heap[T]) Len() int { return (*genHeap[T])(h).Len() }
+
+// Push pushes u onto the heap.
+func (h *Heap[T]) Push(u *T) { heap.Push((*genHeap[T])(h), u) }
+
+// Pop pops the next element in heap order from the heap.
+//
+// Pop on an empty heap causes a panic.  Use Len as needed to avoid this.
+func (h *Heap[T]) Pop() *T { return heap.Pop((*genHeap[T])(h)).(*T) }
+
+// Remove removes u from the heap.
+//
+// Remove will panic if the heap was not constructed with an index, either
+// via WithIndex or, for a Heap built with NewFor, an `index` struct tag.
+func (h *Heap[T]) Remove(u *T) *T {
+	g := (*genHeap[T])(h)
+	if g.getIndex == nil {
+		panic("remove index field not defined")
+	}
+	return heap.Remove(g, g.getIndex(u)).(*T)
+}
+
+// Fix re-establishes heap order for u after its key field has been
+// changed, without removing it from the heap.
+//
+// Fix will panic if the heap was not constructed with an index, either
+// via WithIndex or, for a Heap built with NewFor, an `index` struct tag.
+func (h *Heap[T]) Fix(u *T) {
+	g := (*genHeap[T])(h)
+	if g.getIndex == nil {
+		panic("fix index field not defined")
+	}
+	heap.Fix(g, g.getIndex(u))
+}
@@ -0,0 +1,149 @@
+package tagheap_test
+
+import (
+	"fmt"
+
+	"github.com/soniakeys/tagheap"
+)
+
+func ExampleNewGeneric() {
+	type job struct {
+		pri int
+		idx int
+	}
+	h := tagheap.NewGeneric(
+		func(a, b *job) bool { return a.pri < b.pri },
+		tagheap.WithIndex(
+			func(u *job) int { return u.idx },
+			func(u *job, i int) { u.idx = i }),
+	)
+	h.Push(&job{pri: 3})
+	h.Push(&job{pri: 1})
+	four := &job{pri: 4}
+	h.Push(four)
+	h.Push(&job{pri: 1})
+	h.Push(&job{pri: 5})
+	fmt.Println(h.Pop().pri)
+	h.Remove(four)
+	fmt.Println(h.Pop().pri)
+	fmt.Println(h.Pop().pri)
+	fmt.Println(h.Pop().pri)
+	// Output:
+	// 1
+	// 1
+	// 3
+	// 5
+}
+
+func ExampleHeap_Fix() {
+	type job struct {
+		pri int
+		idx int
+	}
+	h := tagheap.NewGeneric(
+		func(a, b *job) bool { return a.pri < b.pri },
+		tagheap.WithIndex(
+			func(u *job) int { return u.idx },
+			func(u *job, i int) { u.idx = i }),
+	)
+	a := &job{pri: 3}
+	h.Push(a)
+	h.Push(&job{pri: 1})
+	h.Push(&job{pri: 5})
+	a.pri = 0
+	h.Fix(a)
+	fmt.Println(h.Pop().pri)
+	fmt.Println(h.Pop().pri)
+	fmt.Println(h.Pop().pri)
+	// Output:
+	// 0
+	// 1
+	// 5
+}
+
+func ExampleNewFor() {
+	type job struct {
+		N int `heap:"min"`
+		X int `heap:"index"`
+	}
+	h, err := tagheap.NewFor[job](`heap`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	h.Push(&job{N: 3})
+	h.Push(&job{N: 1})
+	four := &job{N: 4}
+	h.Push(four)
+	h.Push(&job{N: 1})
+	h.Push(&job{N: 5})
+	fmt.Println(h.Pop().N)
+	h.Remove(four)
+	fmt.Println(h.Pop().N)
+	fmt.Println(h.Pop().N)
+	fmt.Println(h.Pop().N)
+	// Output:
+	// 1
+	// 1
+	// 3
+	// 5
+}
+
+func ExampleNewFor_registeredComparator() {
+	type version struct {
+		major, minor int
+	}
+	tagheap.RegisterLessTyped("byVersionFor", func(a, b version) bool {
+		if a.major != b.major {
+			return a.major < b.major
+		}
+		return a.minor < b.minor
+	})
+
+	type release struct {
+		V version `heap:"min,cmp=byVersionFor"`
+	}
+	h, err := tagheap.NewFor[release](`heap`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	h.Push(&release{version{1, 2}})
+	h.Push(&release{version{1, 0}})
+	h.Push(&release{version{0, 9}})
+	for h.Len() > 0 {
+		fmt.Println(h.Pop().V)
+	}
+	// Output:
+	// {0 9}
+	// {1 0}
+	// {1 2}
+}
+
+func ExampleNewFor_composite() {
+	// Priority is the primary key (min-heap, rank 1); Seq breaks ties
+	// in FIFO order (min-heap, rank 2) -- the same composite grammar
+	// New accepts.
+	type job struct {
+		Priority int `heap:"min,1"`
+		Seq      int `heap:"min,2"`
+	}
+	h, err := tagheap.NewFor[job](`heap`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	h.Push(&job{Priority: 1, Seq: 0})
+	h.Push(&job{Priority: 0, Seq: 1})
+	h.Push(&job{Priority: 1, Seq: 2})
+	h.Push(&job{Priority: 0, Seq: 3})
+	for h.Len() > 0 {
+		e := h.Pop()
+		fmt.Println(e.Priority, e.Seq)
+	}
+	// Output:
+	// 0 1
+	// 0 3
+	// 1 0
+	// 1 2
+}
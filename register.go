@@ -0,0 +1,42 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package tagheap
+
+import "sync"
+
+var (
+	lessRegistryMu sync.RWMutex
+	lessRegistry   = map[string]func(a, b interface{}) bool{}
+)
+
+// RegisterLess registers a comparator under name for use with the
+// `heap:"min,cmp=name"` and `heap:"max,cmp=name"` struct tag form,
+// enabling key fields of types the built-in kind switch cannot order on
+// its own, such as time.Time, *big.Int, byte slices, or custom structs.
+//
+// less must report whether a should sort before b; both arguments are
+// the key field's value, converted to interface{}.  RegisterLess is
+// typically called from an init function before any affected heap is
+// constructed.
+func RegisterLess(name string, less func(a, b interface{}) bool) {
+	lessRegistryMu.Lock()
+	defer lessRegistryMu.Unlock()
+	lessRegistry[name] = less
+}
+
+// RegisterLessTyped is a type-safe wrapper around RegisterLess for use
+// with the generic Heap[T] API; it spares the caller from type-asserting
+// a and b out of interface{}.
+func RegisterLessTyped[T any](name string, less func(a, b T) bool) {
+	RegisterLess(name, func(a, b interface{}) bool {
+		return less(a.(T), b.(T))
+	})
+}
+
+func lookupLess(name string) (func(a, b interface{}) bool, bool) {
+	lessRegistryMu.RLock()
+	defer lessRegistryMu.RUnlock()
+	less, ok := lessRegistry[name]
+	return less, ok
+}
@@ -0,0 +1,180 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package tagheap
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// DropPolicy selects what a bounded SyncHeap does when a Push arrives
+// while the heap is already at capacity.
+type DropPolicy int
+
+const (
+	// DropBlock makes Push wait until room is available.
+	DropBlock DropPolicy = iota
+	// DropIncoming discards the element being pushed.
+	DropIncoming
+	// DropWorst pushes the new element, then evicts whichever element,
+	// old or new, the heap order favors least.
+	DropWorst
+)
+
+// SyncOption configures a SyncHeap constructed with NewSync.
+type SyncOption func(*SyncHeap)
+
+// WithCapacity bounds a SyncHeap to n elements, applying policy once the
+// heap is full.
+func WithCapacity(n int, policy DropPolicy) SyncOption {
+	return func(sh *SyncHeap) {
+		sh.capacity = n
+		sh.policy = policy
+	}
+}
+
+// SyncHeap is a TagHeap guarded by a mutex, usable concurrently from
+// multiple goroutines.  It can optionally be bounded, and it offers
+// context-aware blocking variants of Push and Pop, making it suitable as
+// a work queue for a pool of goroutines.
+type SyncHeap struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	th       *tagHeap
+	capacity int
+	policy   DropPolicy
+}
+
+// NewSync constructs a new SyncHeap object.
+//
+// Arguments key and ps are as for New.  opts may include WithCapacity to
+// bound the heap.
+func NewSync(key string, ps interface{}, opts ...SyncOption) (*SyncHeap, error) {
+	th, err := newTagHeap(key, ps)
+	if err != nil {
+		return nil, err
+	}
+	sh := &SyncHeap{th: th, capacity: -1}
+	for _, opt := range opts {
+		opt(sh)
+	}
+	sh.cond = sync.NewCond(&sh.mu)
+	return sh, nil
+}
+
+// Len returns the number of structs on the heap.
+func (sh *SyncHeap) Len() int {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return sh.th.Len()
+}
+
+// Push performs a heap push operation, pushing a struct onto the heap.
+//
+// If the heap is bounded and full, Push applies its DropPolicy: under
+// DropIncoming it discards u; under DropWorst it pushes u and then evicts
+// the worst element; under DropBlock it waits for room, as PushWait does
+// without a context. See TagHeap.Push for the panic conditions on u.
+func (sh *SyncHeap) Push(u interface{}) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	for sh.capacity >= 0 && sh.policy == DropBlock && sh.th.Len() >= sh.capacity {
+		sh.cond.Wait()
+	}
+	sh.pushLocked(u)
+	sh.cond.Broadcast()
+}
+
+// pushLocked pushes u and, if the heap is over capacity under DropWorst,
+// evicts the worst element. The caller must hold sh.mu.
+func (sh *SyncHeap) pushLocked(u interface{}) {
+	if sh.capacity >= 0 && sh.policy == DropIncoming && sh.th.Len() >= sh.capacity {
+		return
+	}
+	heap.Push(sh.th, u)
+	if sh.capacity >= 0 && sh.policy == DropWorst && sh.th.Len() > sh.capacity {
+		heap.Remove(sh.th, sh.th.worstIndex())
+	}
+}
+
+// Pop performs a heap pop operation, popping the next struct in heap
+// order from the heap.
+//
+// Pop on an empty heap causes a panic.  Use Len or PopWait as needed to
+// avoid this.
+func (sh *SyncHeap) Pop() interface{} {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	r := heap.Pop(sh.th)
+	sh.cond.Broadcast()
+	return r
+}
+
+// Remove performs a heap remove operation, removing the specified
+// struct. See TagHeap.Remove for the panic conditions on u.
+func (sh *SyncHeap) Remove(u interface{}) interface{} {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	r := (*TagHeap)(sh.th).Remove(u)
+	sh.cond.Broadcast()
+	return r
+}
+
+// Fix re-establishes heap order for u after its key field has been
+// changed. See TagHeap.Fix for the panic conditions on u.
+func (sh *SyncHeap) Fix(u interface{}) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	(*TagHeap)(sh.th).Fix(u)
+	sh.cond.Broadcast()
+}
+
+// wakeAll broadcasts on the condition variable while holding sh.mu, so
+// that a waiter already past its ctx.Err() check but not yet in Wait
+// cannot miss the wakeup.
+func (sh *SyncHeap) wakeAll() {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.cond.Broadcast()
+}
+
+// PopWait waits until the heap is non-empty and pops the next struct in
+// heap order, or returns ctx.Err() if ctx is done first.
+func (sh *SyncHeap) PopWait(ctx context.Context) (interface{}, error) {
+	stop := context.AfterFunc(ctx, sh.wakeAll)
+	defer stop()
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	for sh.th.Len() == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sh.cond.Wait()
+	}
+	r := heap.Pop(sh.th)
+	sh.cond.Broadcast()
+	return r, nil
+}
+
+// PushWait waits until a bounded heap has room and pushes u, or returns
+// ctx.Err() if ctx is done first.  On an unbounded heap, or one whose
+// DropPolicy is not DropBlock, PushWait pushes immediately.
+func (sh *SyncHeap) PushWait(ctx context.Context, u interface{}) error {
+	stop := context.AfterFunc(ctx, sh.wakeAll)
+	defer stop()
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	for sh.capacity >= 0 && sh.policy == DropBlock && sh.th.Len() >= sh.capacity {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		sh.cond.Wait()
+	}
+	sh.pushLocked(u)
+	sh.cond.Broadcast()
+	return nil
+}
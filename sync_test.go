@@ -0,0 +1,248 @@
+package tagheap_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/soniakeys/tagheap"
+)
+
+func TestSyncPopWait(t *testing.T) {
+	type s struct {
+		N int `heap:"min"`
+	}
+	var s0 []*s
+	h, err := tagheap.NewSync(`heap`, &s0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan *s, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		u, err := h.PopWait(ctx)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		done <- u.(*s)
+	}()
+
+	time.Sleep(10 * time.Millisecond) // let PopWait start waiting
+	h.Push(&s{N: 7})
+
+	select {
+	case got := <-done:
+		if got.N != 7 {
+			t.Fatalf("got %d, want 7", got.N)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PopWait did not return after Push")
+	}
+}
+
+func TestSyncBoundedDropWorst(t *testing.T) {
+	type s struct {
+		N int `heap:"max"`
+	}
+	var s0 []*s
+	h, err := tagheap.NewSync(`heap`, &s0,
+		tagheap.WithCapacity(2, tagheap.DropWorst))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Push(&s{N: 5})
+	h.Push(&s{N: 1})
+	h.Push(&s{N: 3}) // 1 is the worst of a max-heap; it should be evicted
+
+	if n := h.Len(); n != 2 {
+		t.Fatalf("Len() = %d, want 2", n)
+	}
+	if got := h.Pop().(*s).N; got != 5 {
+		t.Fatalf("got %d, want 5", got)
+	}
+	if got := h.Pop().(*s).N; got != 3 {
+		t.Fatalf("got %d, want 3", got)
+	}
+}
+
+func TestSyncBoundedDropIncoming(t *testing.T) {
+	type s struct {
+		N int `heap:"max"`
+	}
+	var s0 []*s
+	h, err := tagheap.NewSync(`heap`, &s0,
+		tagheap.WithCapacity(2, tagheap.DropIncoming))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Push(&s{N: 5})
+	h.Push(&s{N: 1})
+	h.Push(&s{N: 3}) // heap is full; the incoming 3 should be discarded
+
+	if n := h.Len(); n != 2 {
+		t.Fatalf("Len() = %d, want 2", n)
+	}
+	if got := h.Pop().(*s).N; got != 5 {
+		t.Fatalf("got %d, want 5", got)
+	}
+	if got := h.Pop().(*s).N; got != 1 {
+		t.Fatalf("got %d, want 1", got)
+	}
+}
+
+func TestSyncBoundedDropBlockPush(t *testing.T) {
+	type s struct {
+		N int `heap:"min"`
+	}
+	var s0 []*s
+	h, err := tagheap.NewSync(`heap`, &s0,
+		tagheap.WithCapacity(1, tagheap.DropBlock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Push(&s{N: 1}) // fills the heap to capacity
+
+	done := make(chan struct{})
+	go func() {
+		h.Push(&s{N: 2}) // should block until the Pop below makes room
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("blocking Push returned before room was made")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	h.Pop()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("blocking Push did not unblock after Pop made room")
+	}
+
+	if n := h.Len(); n != 1 {
+		t.Fatalf("Len() = %d, want 1", n)
+	}
+}
+
+func TestSyncPushWaitBlocks(t *testing.T) {
+	type s struct {
+		N int `heap:"min"`
+	}
+	var s0 []*s
+	h, err := tagheap.NewSync(`heap`, &s0,
+		tagheap.WithCapacity(1, tagheap.DropBlock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Push(&s{N: 1}) // fills the heap to capacity
+
+	done := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		done <- h.PushWait(ctx, &s{N: 2})
+	}()
+
+	time.Sleep(10 * time.Millisecond) // let PushWait start waiting
+	h.Pop()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("PushWait returned error %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PushWait did not return after Pop made room")
+	}
+
+	if n := h.Len(); n != 1 {
+		t.Fatalf("Len() = %d, want 1", n)
+	}
+}
+
+func TestSyncPushWaitTimeout(t *testing.T) {
+	type s struct {
+		N int `heap:"min"`
+	}
+	var s0 []*s
+	h, err := tagheap.NewSync(`heap`, &s0,
+		tagheap.WithCapacity(1, tagheap.DropBlock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Push(&s{N: 1}) // fills the heap to capacity; never drained below
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := h.PushWait(ctx, &s{N: 2}); err != ctx.Err() {
+		t.Fatalf("PushWait error = %v, want %v", err, ctx.Err())
+	}
+	if n := h.Len(); n != 1 {
+		t.Fatalf("Len() = %d, want 1 (rejected push should not land)", n)
+	}
+}
+
+func TestSyncRemove(t *testing.T) {
+	type s struct {
+		N int `heap:"min"`
+		X int `heap:"index"`
+	}
+	var s0 []*s
+	h, err := tagheap.NewSync(`heap`, &s0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Push(&s{N: 3})
+	h.Push(&s{N: 1})
+	four := &s{N: 4}
+	h.Push(four)
+	h.Push(&s{N: 5})
+
+	h.Remove(four)
+
+	want := []int{1, 3, 5}
+	for _, w := range want {
+		if got := h.Pop().(*s).N; got != w {
+			t.Fatalf("got %d, want %d", got, w)
+		}
+	}
+}
+
+func TestSyncFixReheapifies(t *testing.T) {
+	type s struct {
+		N int `heap:"min"`
+		X int `heap:"index"`
+	}
+	var s0 []*s
+	h, err := tagheap.NewSync(`heap`, &s0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &s{N: 3}
+	h.Push(a)
+	h.Push(&s{N: 1})
+	h.Push(&s{N: 5})
+
+	a.N = 0
+	h.Fix(a)
+
+	want := []int{0, 1, 5}
+	for _, w := range want {
+		if got := h.Pop().(*s).N; got != w {
+			t.Fatalf("got %d, want %d", got, w)
+		}
+	}
+}
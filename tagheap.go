@@ -16,8 +16,8 @@
 // that is, it must be a string, integer, or floating point type.
 //
 // The struct tag `heap:"max"` similarly indicates a key field and
-// specifies a max-heap.  There must be exactly one key field in the struct,
-// either min or max.
+// specifies a max-heap.  There must be at least one key field in the
+// struct, either min or max.
 //
 // Optionally, another field may have the tag `heap:"index"`.  The tag
 // specifies that tagHeap methods should maintain this field as an index
@@ -25,6 +25,26 @@
 // This field can be thought of as a "cookie" that is needed by Remove but
 // should otherwise be ignored.
 //
+// Composite keys
+//
+// A struct may declare more than one key field to get a tie-breaker
+// sequence, for example a min-heap on a Priority field, falling back to a
+// max-heap on a Timestamp field to break ties.  Each key field's tag takes
+// an optional rank after the min/max direction, as in `heap:"min,1"` and
+// `heap:"max,2"`.  Key fields are compared in ascending rank order, and
+// the heap orders on the first field where two elements differ.  A key
+// field with no rank is equivalent to rank 0.
+//
+// Registered comparators
+//
+// A key field is not limited to the string, integer, and floating point
+// types New understands natively.  RegisterLess associates a name with a
+// func(a, b interface{}) bool comparator; a key field tagged with
+// `heap:"min,cmp=name"` or `heap:"max,cmp=name"` is ordered with that
+// comparator instead of the built-in kind switch, enabling keys such as
+// time.Time, *big.Int, byte slices, or any other type the caller can
+// compare.
+//
 // Multiple heaps
 //
 // The word "key" also refers to the key portion of the key-value syntax
@@ -43,6 +63,9 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // TagHeap exports heap functions.
@@ -116,14 +139,51 @@ func (t *TagHeap) Remove(u interface{}) interface{} {
 		int(reflect.ValueOf(u).Elem().Field(th.indexFieldIndex).Int()))
 }
 
+// Fix re-establishes heap order for u after its key field has been
+// changed, without removing it from the heap.  This is the "decrease-key"
+// operation used by algorithms such as Dijkstra's and A*.
+//
+// Fix will panic if an index field is not defined.  The argument u
+// must be a pointer to struct, of the struct type supplied to New.
+// An invalid argument type causes a panic.
+func (t *TagHeap) Fix(u interface{}) {
+	th := (*tagHeap)(t)
+	if th.indexFieldIndex < 0 {
+		panic("fix index field not defined")
+	}
+	if !reflect.TypeOf(u).ConvertibleTo(th.pt) {
+		panic("invalid type for fix argument")
+	}
+	heap.Fix(th, int(reflect.ValueOf(u).Elem().Field(th.indexFieldIndex).Int()))
+}
+
+// Update applies mutate to u, which is presumed to change u's key field,
+// then fixes the heap so that u is back in its correct position.
+//
+// Update will panic if an index field is not defined.  The argument u
+// must be a pointer to struct, of the struct type supplied to New.
+// An invalid argument type causes a panic.
+func (t *TagHeap) Update(u interface{}, mutate func(interface{})) {
+	mutate(u)
+	t.Fix(u)
+}
+
+// keyField describes one ordering field of a composite key, in the rank
+// order the key fields are compared.
+type keyField struct {
+	fieldIndex int
+	minHeap    bool
+	less       func(vi, vj reflect.Value) bool
+	rank       int
+}
+
 // unexported type implementing heap.Interface
 type tagHeap struct {
 	s               reflect.Value // assignable slice of ptr to struct
 	pt              reflect.Type  // pointer to struct
-	minHeap         bool
-	keyFieldIndex   int
-	indexFieldIndex int
+	keyFields       []keyField    // sorted by rank
 	less            func(vi, vj reflect.Value) bool
+	indexFieldIndex int
 	swapTemp        reflect.Value // assignable pointer to struct
 }
 
@@ -135,7 +195,6 @@ func newTagHeap(key string, ps interface{}) (*tagHeap, error) {
 	}
 	// create return value
 	s := &tagHeap{
-		keyFieldIndex:   -1,
 		indexFieldIndex: -1,
 	}
 	if at.Kind() != reflect.Ptr {
@@ -153,59 +212,136 @@ func newTagHeap(key string, ps interface{}) (*tagHeap, error) {
 	if st.Kind() != reflect.Struct {
 		return nil, errors.New("argument must be pointer to slice of pointer to struct")
 	}
-	// find and validate struct tags
+	keyFields, indexFieldIndex, err := parseTagFields(st, key)
+	if err != nil {
+		return nil, err
+	}
+	s.keyFields = keyFields
+	s.less = compositeLess(keyFields)
+	s.indexFieldIndex = indexFieldIndex
+	// initialize s.s, swapTemp
+	s.s = reflect.ValueOf(ps).Elem()
+	s.swapTemp = reflect.New(s.pt).Elem()
+	heap.Init(s)
+	return s, nil
+}
+
+// parseTagFields walks the exported fields of struct type st, collecting
+// the key fields (`heap:"min"`, `heap:"max"`, their composite ",rank" and
+// ",cmp=name" forms) and the index field (`heap:"index"`) tagged with the
+// given key.  It is shared by newTagHeap and NewFor so that New and
+// NewFor recognize exactly the same tag grammar.
+//
+// keyFields is sorted by rank; indexFieldIndex is -1 if no index field
+// was tagged.
+func parseTagFields(st reflect.Type, key string) (keyFields []keyField, indexFieldIndex int, err error) {
+	indexFieldIndex = -1
 	for i, n := 0, st.NumField(); i < n; i++ {
 		sf := st.Field(i)
-		switch tv := sf.Tag.Get(key); tv {
-		case "":
+		tv := sf.Tag.Get(key)
+		switch {
+		case tv == "":
 			continue
-		case "min", "max":
+		case tv == "index":
 			if sf.PkgPath > "" {
-				return nil, errors.New("key field must be exported")
+				return nil, -1, errors.New("index field must be exported")
 			}
-			if s.keyFieldIndex >= 0 {
-				return nil, errors.New("struct tags specify multiple keys.")
+			if indexFieldIndex >= 0 {
+				return nil, -1, errors.New("struct tags specify multiple indexes")
 			}
-			switch k := sf.Type.Kind(); {
-			case k == reflect.String:
-				s.less = lessString
-			case k >= reflect.Int && k <= reflect.Int64:
-				s.less = lessInt
-			case k >= reflect.Uint && k <= reflect.Uint64:
-				s.less = lessUint
-			case k == reflect.Float64 || k == reflect.Float32:
-				s.less = lessFloat
-			default:
-				return nil, errors.New("key field must be " +
-					"a string, integer, or floating point type")
+			if sf.Type.Kind() != reflect.Int {
+				return nil, -1, errors.New("index field must have type int")
 			}
-			s.keyFieldIndex = i
-			if tv == "min" {
-				s.minHeap = true
+			indexFieldIndex = i
+		default:
+			dir, rank, cmpName, err := parseKeyTag(tv)
+			if err != nil {
+				return nil, -1, err
 			}
-		case "index":
 			if sf.PkgPath > "" {
-				return nil, errors.New("index field must be exported")
+				return nil, -1, errors.New("key field must be exported")
 			}
-			if s.indexFieldIndex >= 0 {
-				return nil, errors.New("struct tags specify multiple indexes")
+			kf := keyField{fieldIndex: i, minHeap: dir == "min", rank: rank}
+			if cmpName != "" {
+				less, ok := lookupLess(cmpName)
+				if !ok {
+					return nil, -1, fmt.Errorf("no comparator registered under name %q", cmpName)
+				}
+				kf.less = func(vi, vj reflect.Value) bool {
+					return less(vi.Interface(), vj.Interface())
+				}
+			} else {
+				switch k := sf.Type.Kind(); {
+				case k == reflect.String:
+					kf.less = lessString
+				case k >= reflect.Int && k <= reflect.Int64:
+					kf.less = lessInt
+				case k >= reflect.Uint && k <= reflect.Uint64:
+					kf.less = lessUint
+				case k == reflect.Float64 || k == reflect.Float32:
+					kf.less = lessFloat
+				default:
+					return nil, -1, errors.New("key field must be " +
+						"a string, integer, or floating point type")
+				}
 			}
-			if sf.Type.Kind() != reflect.Int {
-				return nil, errors.New("index field must have type int")
+			keyFields = append(keyFields, kf)
+		}
+	}
+	if len(keyFields) == 0 {
+		return nil, -1, errors.New("struct must indicate key field")
+	}
+	sort.SliceStable(keyFields, func(i, j int) bool {
+		return keyFields[i].rank < keyFields[j].rank
+	})
+	return keyFields, indexFieldIndex, nil
+}
+
+// compositeLess builds a less function comparing two struct values field
+// by field over keyFields, in rank order, returning at the first field
+// where the two elements differ.
+func compositeLess(keyFields []keyField) func(vi, vj reflect.Value) bool {
+	return func(vi, vj reflect.Value) bool {
+		for _, kf := range keyFields {
+			fi := vi.Field(kf.fieldIndex)
+			fj := vj.Field(kf.fieldIndex)
+			iLess := kf.less(fi, fj)
+			jLess := kf.less(fj, fi)
+			if iLess == jLess {
+				continue
 			}
-			s.indexFieldIndex = i
-		default:
-			return nil, fmt.Errorf("invalid struct tag %q", tv)
+			if kf.minHeap {
+				return iLess
+			}
+			return jLess
 		}
+		return false
+	}
+}
+
+// parseKeyTag parses the "min" or "max" portion of a key field tag, along
+// with its optional ",rank" and ",cmp=name" suffixes, as in `heap:"min,1"`
+// and `heap:"min,cmp=byDeadline"`.  A key field with no rank is equivalent
+// to rank 0; a key field with no cmp uses the built-in kind switch.
+func parseKeyTag(tv string) (dir string, rank int, cmpName string, err error) {
+	parts := strings.Split(tv, ",")
+	switch parts[0] {
+	case "min", "max":
+		dir = parts[0]
+	default:
+		return "", 0, "", fmt.Errorf("invalid struct tag %q", tv)
 	}
-	if s.keyFieldIndex < 0 {
-		return nil, errors.New("struct must indicate key field")
+	for _, p := range parts[1:] {
+		if n, ok := strings.CutPrefix(p, "cmp="); ok {
+			cmpName = n
+			continue
+		}
+		rank, err = strconv.Atoi(p)
+		if err != nil {
+			return "", 0, "", fmt.Errorf("invalid struct tag %q", tv)
+		}
 	}
-	// initialize s.s, swapTemp
-	s.s = reflect.ValueOf(ps).Elem()
-	s.swapTemp = reflect.New(s.pt).Elem()
-	heap.Init(s)
-	return s, nil
+	return dir, rank, cmpName, nil
 }
 
 func lessString(vi, vj reflect.Value) bool { return vi.String() < vj.String() }
@@ -218,9 +354,25 @@ func (s tagHeap) Len() int { return s.s.Len() }
 
 // method of heap.Interface
 func (s tagHeap) Less(i, j int) bool {
-	return s.less(
-		s.s.Index(i).Elem().Field(s.keyFieldIndex),
-		s.s.Index(j).Elem().Field(s.keyFieldIndex)) == s.minHeap
+	return s.less(s.s.Index(i).Elem(), s.s.Index(j).Elem())
+}
+
+// worstIndex returns the index of the element least favored by the heap
+// order, i.e. the element a bounded SyncHeap should evict first when it
+// is full.  The worst element is always a leaf, so only the lower half
+// of the slice needs to be scanned.
+func (s tagHeap) worstIndex() int {
+	n := s.Len()
+	if n == 0 {
+		return -1
+	}
+	worst := n / 2
+	for i := worst + 1; i < n; i++ {
+		if s.Less(worst, i) {
+			worst = i
+		}
+	}
+	return worst
 }
 
 // method of heap.Interface
@@ -46,6 +46,121 @@ func ExampleTagHeap_Remove() {
 	// 5
 }
 
+func ExampleTagHeap_Update() {
+	type s struct {
+		N int `heap:"min"`
+		X int `heap:"index"`
+	}
+	var s0 []*s
+	h, err := tagheap.New(`heap`, &s0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	a := &s{N: 3}
+	h.Push(a)
+	h.Push(&s{N: 1})
+	h.Push(&s{N: 5})
+	h.Update(a, func(u interface{}) { u.(*s).N = 0 })
+	fmt.Println(h.Pop().(*s).N)
+	fmt.Println(h.Pop().(*s).N)
+	fmt.Println(h.Pop().(*s).N)
+	// Output:
+	// 0
+	// 1
+	// 5
+}
+
+func ExampleNew_composite() {
+	// Priority is the primary key (min-heap, rank 1); Seq breaks ties
+	// in FIFO order (min-heap, rank 2).
+	type s struct {
+		Priority int `heap:"min,1"`
+		Seq      int `heap:"min,2"`
+	}
+	var s0 []*s
+	h, err := tagheap.New(`heap`, &s0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	h.Push(&s{Priority: 1, Seq: 0})
+	h.Push(&s{Priority: 0, Seq: 1})
+	h.Push(&s{Priority: 1, Seq: 2})
+	h.Push(&s{Priority: 0, Seq: 3})
+	for h.Len() > 0 {
+		e := h.Pop().(*s)
+		fmt.Println(e.Priority, e.Seq)
+	}
+	// Output:
+	// 0 1
+	// 0 3
+	// 1 0
+	// 1 2
+}
+
+func ExampleNew_compositeMaxTie() {
+	// Priority is the primary key (max-heap, rank 1); Seq breaks ties
+	// in FIFO order (min-heap, rank 2). Every element below shares the
+	// same Priority, so the tie-breaker on Seq must decide the order.
+	type s struct {
+		Priority int `heap:"max,1"`
+		Seq      int `heap:"min,2"`
+	}
+	var s0 []*s
+	h, err := tagheap.New(`heap`, &s0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	h.Push(&s{Priority: 5, Seq: 1})
+	h.Push(&s{Priority: 5, Seq: 2})
+	h.Push(&s{Priority: 5, Seq: 3})
+	for h.Len() > 0 {
+		fmt.Println(h.Pop().(*s).Seq)
+	}
+	// Output:
+	// 1
+	// 2
+	// 3
+}
+
+func TestRegisterLess(t *testing.T) {
+	type version struct {
+		major, minor int
+	}
+	tagheap.RegisterLess("byVersion", func(a, b interface{}) bool {
+		av, bv := a.(version), b.(version)
+		if av.major != bv.major {
+			return av.major < bv.major
+		}
+		return av.minor < bv.minor
+	})
+
+	type s struct {
+		V version `heap:"min,cmp=byVersion"`
+	}
+	s0 := []*s{
+		{version{1, 2}},
+		{version{1, 0}},
+		{version{0, 9}},
+	}
+	h, err := tagheap.New(`heap`, &s0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Push(&s{version{1, 1}})
+
+	want := []version{{0, 9}, {1, 0}, {1, 1}, {1, 2}}
+	for _, w := range want {
+		got := h.Pop().(*s).V
+		if got != w {
+			t.Fatalf("got %v, want %v", got, w)
+		}
+	}
+}
+
 func TestSingle(t *testing.T) {
 	// test some settings to contrast with the example above.
 	type s struct {